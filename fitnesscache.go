@@ -0,0 +1,90 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+// fitnessCache is a size-bounded, concurrency-safe LRU cache from genome
+// string to its evaluated fitness. Evaluate consults it before running the
+// VM and populates it on exit, so identical genomes re-appearing across
+// generations don't pay for re-evaluation. A nil *fitnessCache is a valid,
+// always-empty cache, so callers that don't opt in via --fitness-cache-size
+// don't need a nil check of their own.
+type fitnessCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+
+	hits   uint64
+	misses uint64
+}
+
+type cacheEntry struct {
+	key     string
+	fitness float64
+}
+
+// newFitnessCache builds a cache holding at most capacity entries. A
+// non-positive capacity disables caching.
+func newFitnessCache(capacity int) *fitnessCache {
+	return &fitnessCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *fitnessCache) get(key string) (float64, bool) {
+	if c == nil || c.capacity <= 0 {
+		return 0, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return 0, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits++
+	return elem.Value.(*cacheEntry).fitness, true
+}
+
+func (c *fitnessCache) put(key string, fitness float64) {
+	if c == nil || c.capacity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).fitness = fitness
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, fitness: fitness})
+
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// counts reports cumulative hit/miss totals since the cache was created.
+func (c *fitnessCache) counts() (hits, misses uint64) {
+	if c == nil {
+		return 0, 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
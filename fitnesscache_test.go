@@ -0,0 +1,91 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFitnessCacheHitsAndMisses(t *testing.T) {
+	c := newFitnessCache(10)
+
+	if _, ok := c.get("a"); ok {
+		t.Fatalf("expected a miss on an empty cache")
+	}
+
+	c.put("a", 1.5)
+	fitness, ok := c.get("a")
+	if !ok || fitness != 1.5 {
+		t.Fatalf("expected a hit with fitness 1.5, got %v, %v", fitness, ok)
+	}
+
+	hits, misses := c.counts()
+	if hits != 1 || misses != 1 {
+		t.Fatalf("expected 1 hit and 1 miss, got hits=%d misses=%d", hits, misses)
+	}
+}
+
+func TestFitnessCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newFitnessCache(2)
+
+	c.put("a", 1.0)
+	c.put("b", 2.0)
+
+	// Touch "a" so "b" becomes the least recently used entry.
+	c.get("a")
+
+	c.put("c", 3.0)
+
+	if _, ok := c.get("b"); ok {
+		t.Fatalf("expected \"b\" to have been evicted as least recently used")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Fatalf("expected \"a\" to survive eviction")
+	}
+	if _, ok := c.get("c"); !ok {
+		t.Fatalf("expected \"c\" to have been inserted")
+	}
+}
+
+func TestFitnessCacheZeroCapacityDisablesCaching(t *testing.T) {
+	c := newFitnessCache(0)
+
+	c.put("a", 1.0)
+	if _, ok := c.get("a"); ok {
+		t.Fatalf("expected a zero-capacity cache to never report a hit")
+	}
+}
+
+func TestNilFitnessCacheIsSafe(t *testing.T) {
+	var c *fitnessCache
+
+	if _, ok := c.get("a"); ok {
+		t.Fatalf("expected a nil cache to always miss")
+	}
+	c.put("a", 1.0) // should not panic
+
+	hits, misses := c.counts()
+	if hits != 0 || misses != 0 {
+		t.Fatalf("expected a nil cache to report zero counts, got hits=%d misses=%d", hits, misses)
+	}
+}
+
+func TestFitnessCacheConcurrentAccess(t *testing.T) {
+	c := newFitnessCache(100)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := string(rune('a' + i%26))
+			c.put(key, float64(i))
+			c.get(key)
+		}(i)
+	}
+	wg.Wait()
+
+	hits, misses := c.counts()
+	if hits+misses == 0 {
+		t.Fatalf("expected concurrent gets to register in the hit/miss counts")
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"math"
 	"math/rand"
@@ -9,138 +10,158 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/MaxHalford/eaopt"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/shteou/go-al/vm"
 )
 
 var (
-	corpus       = strings.Split("ABCDEFGHI", "")
-	genomeLength uint
+	activeInstructionSet vm.InstructionSet
+	genomeLength         uint
+	activeFitnessCache   *fitnessCache
 )
 
 type Genome []string
 
-type GenomeState struct {
-	Children  uint
-	Energy    float64
-	FoundFood bool
-	Size      uint
-	Threat    float64
-}
+// Genome's operators take a pointer receiver because variable-length mode
+// lets Mutate and Crossover resize the underlying slice; a value receiver
+// would lose that resize the moment the method returned.
+
+func (G *Genome) Evaluate() (fitness float64, err error) {
+	key := strings.Join(*G, "")
+
+	if cached, ok := activeFitnessCache.get(key); ok {
+		return cached, nil
+	}
+
+	program := make([]byte, len(*G))
+	for i, gene := range *G {
+		program[i] = gene[0]
+	}
 
-func logWithFields(g *GenomeState) *log.Entry {
-	return log.WithFields(log.Fields{
-		"state": g,
-	})
+	state, err := vm.Run(program, activeInstructionSet, 1000)
+	if err != nil {
+		return 0, err
+	}
+
+	fitness = 1.0 - (float64(state.Children) / 1000.0)
+
+	if variableLength {
+		// Parsimony pressure: prefer shorter programs among equally fit ones.
+		fitness += parsimonyAlpha * float64(len(*G)) / float64(maxGenomeLength)
+	}
+
+	activeFitnessCache.put(key, fitness)
+
+	return fitness, nil
 }
 
-func (G Genome) Evaluate() (fitness float64, err error) {
-	index := 0
-
-	g := GenomeState{Children: 0, Energy: 10.0, FoundFood: false, Size: 1, Threat: 0}
-
-	for i := 0; i < 1000; i++ {
-		gene := G[index]
-		switch gene[0] {
-		case 'A': // No Op
-			logWithFields(&g).Debug("No Op")
-		case 'B': // Spawn Child
-			if g.Energy > 25.0 {
-				g.Children += 1
-				logWithFields(&g).Debug("Spawn Child succeeded")
-			} else {
-				logWithFields(&g).Debug("Spawn Child failed")
-			}
-			g.Energy -= 25.0
-		case 'C': // Locate Food
-			g.FoundFood = true
-			logWithFields(&g).Debug("Located Food")
-		case 'D': // Eat Food
-			if g.FoundFood {
-				logWithFields(&g).Debug("Ate Food")
-				g.Energy += 10.0 + float64(g.Size)
-				g.Energy = math.Max(g.Energy, float64(g.Size)+15.0)
-			} else {
-				logWithFields(&g).Debug("Eat Food Failed")
-			}
-		case 'E': // Grow
-			logWithFields(&g).Debug("Growing")
-			g.Energy -= math.Pow(float64(g.Size)/2.0, 1.05)
-			g.Size += 1
-		case 'F': // Defend
-			logWithFields(&g).Debug("Defending")
-			g.Energy -= 5.0 / (float64(g.Size) / 2.0)
-			g.Threat -= 5
-			g.Threat = math.Max(g.Threat, 0.0)
-		case 'G': // Evade
-			logWithFields(&g).Debug("Evading")
-			g.Energy -= 1.0 * (float64(g.Size) / 2.0)
-			g.Threat -= 5
-		case 'H': // Skip if low threat
-			if g.Threat < 5.0 {
-				logWithFields(&g).Debug("Skipping due to low threat")
-				index++
-			}
-		case 'I': // Skip if low energy
-			if g.Energy < 30.0 {
-				logWithFields(&g).Debug("Skipping due to low energy")
-				index++
-			}
-		default:
-			logWithFields(&g).Debug("Unexpected")
-		}
+func (G *Genome) Mutate(rng *rand.Rand) {
+	eaopt.MutUniformString(*G, activeInstructionSet.Corpus(), 2, rng)
 
-		// Lose track of food unless we've just found it, or the gene is a no-op
-		if gene != "C" && gene != "A" && gene != "H" && gene != "I" {
-			g.FoundFood = false
-		}
+	if !variableLength {
+		return
+	}
 
-		// Skip energy/threat evaluation if no-op
-		if gene != "A" && gene != "H" && gene != "I" {
-			// Larger organisms require more energy
-			g.Energy -= math.Pow(1.0+float64(g.Size)/40.0, 2.0)
-			g.Threat += 1.0
+	corpus := activeInstructionSet.Corpus()
 
-			if float64(g.Threat) > (20.0 + float64(g.Size)) {
-				g.Energy -= float64(g.Threat)
-			}
-		}
+	if rng.Float64() < insertRate && uint(len(*G)) < maxGenomeLength {
+		pos := rng.Intn(len(*G) + 1)
+		letter := corpus[rng.Intn(len(corpus))]
 
-		// Wasted
-		if g.Energy <= 0.0 {
-			logWithFields(&g).Debugf("Died on iteration %d", i)
-			break
-		}
+		grown := make(Genome, 0, len(*G)+1)
+		grown = append(grown, (*G)[:pos]...)
+		grown = append(grown, letter)
+		grown = append(grown, (*G)[pos:]...)
+		*G = grown
+	}
 
-		// Iterate through the genome, looping at the end
-		index = (index + 1) % len(G)
+	if rng.Float64() < deleteRate && uint(len(*G)) > minGenomeLength {
+		pos := rng.Intn(len(*G))
+
+		shrunk := make(Genome, 0, len(*G)-1)
+		shrunk = append(shrunk, (*G)[:pos]...)
+		shrunk = append(shrunk, (*G)[pos+1:]...)
+		*G = shrunk
 	}
+}
+
+func (G *Genome) Crossover(Y eaopt.Genome, rng *rand.Rand) {
+	other := Y.(*Genome)
+
+	if !variableLength {
+		eaopt.CrossGNXString(*G, *other, 3, rng)
+		return
+	}
+
+	// Cut-and-splice: pick an independent cut point in each parent and swap
+	// the tails, producing children of possibly different lengths.
+	cutA := rng.Intn(len(*G) + 1)
+	cutB := rng.Intn(len(*other) + 1)
 
-	return 1.0 - (float64(g.Children) / 1000.0), nil
+	childA := make(Genome, 0, cutA+len(*other)-cutB)
+	childA = append(childA, (*G)[:cutA]...)
+	childA = append(childA, (*other)[cutB:]...)
+
+	childB := make(Genome, 0, cutB+len(*G)-cutA)
+	childB = append(childB, (*other)[:cutB]...)
+	childB = append(childB, (*G)[cutA:]...)
+
+	*G = clampGenomeLength(childA, rng)
+	*other = clampGenomeLength(childB, rng)
 }
 
-func (G Genome) Mutate(rng *rand.Rand) {
-	eaopt.MutUniformString(G, corpus, 2, rng)
+func MakeStrings(rng *rand.Rand) eaopt.Genome {
+	length := genomeLength
+
+	if variableLength {
+		length = minGenomeLength + uint(rng.Intn(int(maxGenomeLength-minGenomeLength)+1))
+	}
+
+	g := Genome(eaopt.InitUnifString(length, activeInstructionSet.Corpus(), rng))
+	return &g
 }
 
-func (G Genome) Crossover(Y eaopt.Genome, rng *rand.Rand) {
-	eaopt.CrossGNXString(G, Y.(Genome), 3, rng)
+func (G *Genome) Clone() eaopt.Genome {
+	var XX = make(Genome, len(*G))
+	copy(XX, *G)
+	return &XX
 }
 
-func MakeStrings(rng *rand.Rand) eaopt.Genome {
-	return Genome(eaopt.InitUnifString(genomeLength, corpus, rng))
+// stallConfig controls when evolveGenomes should give up on a run that has
+// stopped improving.
+type stallConfig struct {
+	maxGenerations uint
+	maxSeconds     float64
+	targetFitness  float64
 }
 
-func (G Genome) Clone() eaopt.Genome {
-	var XX = make(Genome, len(G))
-	copy(XX, G)
-	return XX
+// varLenConfig turns on variable-length genomes and configures the range
+// and mutation rates that govern them.
+type varLenConfig struct {
+	enabled    bool
+	minLength  uint
+	maxLength  uint
+	insertRate float64
+	deleteRate float64
+	alpha      float64
 }
 
-func evolveGenomes(len uint64) {
+func evolveGenomes(len uint64, stall stallConfig, hillclimb bool, hillclimbCfg hillClimbConfig, species speciesConfig, varLen varLenConfig, fitnessCacheSize int, parallelEval bool) {
 	genomeLength = uint(len)
 
+	activeFitnessCache = newFitnessCache(fitnessCacheSize)
+
+	variableLength = varLen.enabled
+	minGenomeLength = varLen.minLength
+	maxGenomeLength = varLen.maxLength
+	insertRate = varLen.insertRate
+	deleteRate = varLen.deleteRate
+	parsimonyAlpha = varLen.alpha
+
 	var ga, err = eaopt.NewDefaultGAConfig().NewGA()
 	if err != nil {
 		fmt.Println(err)
@@ -152,58 +173,78 @@ func evolveGenomes(len uint64) {
 	ga.PopSize = 40
 	ga.MigFrequency = 5
 	ga.Migrator = eaopt.MigRing{NMigrants: 5}
-	ga.ParallelEval = false
+	// The fitness cache and vm.GenomeState's sync.Pool are built to be safe
+	// under concurrent Evaluate calls, so this can be turned on.
+	ga.ParallelEval = parallelEval
 
 	winner := ""
 	mutex := sync.Mutex{}
 
+	bestFitness := math.Inf(1)
+	lastImprovedGen := uint(0)
+	lastImprovedAt := time.Now()
+
 	// Periodically update progress, or when a new champion is found
 	ga.Callback = func(ga *eaopt.GA) {
 		mutex.Lock()
 		defer mutex.Unlock()
 
 		if ga.Generations%100 == 0 {
-			fmt.Printf("%d)\n", ga.Generations)
+			hits, misses := activeFitnessCache.counts()
+			fmt.Printf("%d) cache hits=%d misses=%d\n", ga.Generations, hits, misses)
+		}
+
+		champion := ga.HallOfFame[0]
+
+		if champion.Fitness < bestFitness {
+			bestFitness = champion.Fitness
+			lastImprovedGen = ga.Generations
+			lastImprovedAt = time.Now()
 		}
 
 		var buffer bytes.Buffer
-		for _, letter := range ga.HallOfFame[0].Genome.(Genome) {
+		for _, letter := range *(champion.Genome.(*Genome)) {
 			buffer.WriteString(letter)
 		}
 
 		if winner != buffer.String() {
 			winner = buffer.String()
-			fmt.Printf("%d) Result -> %s (%d children)\n", ga.Generations, buffer.String(), uint((1.0-ga.HallOfFame[0].Fitness)*1000.0))
+			fmt.Printf("%d) Result -> %s (%d children)\n", ga.Generations, buffer.String(), uint((1.0-champion.Fitness)*1000.0))
 		}
+
+		stalledGenerations := stall.maxGenerations > 0 && ga.Generations-lastImprovedGen >= stall.maxGenerations
+		stalledSeconds := stall.maxSeconds > 0 && time.Since(lastImprovedAt).Seconds() >= stall.maxSeconds
+		reachedTarget := stall.targetFitness > 0 && champion.Fitness <= stall.targetFitness
+
+		if stalledGenerations || stalledSeconds || reachedTarget {
+			// Let the generation in progress finish, then stop the run.
+			ga.NGenerations = ga.Generations
+		}
+
+		applySpeciation(ga, species)
 	}
 
 	// Run the GA
 	ga.Minimize(MakeStrings)
+
+	fmt.Printf("Winner -> %s\n", winner)
+
+	if hillclimb {
+		refined := hillClimb(*(ga.HallOfFame[0].Genome.(*Genome)), hillclimbCfg)
+		fitness, _ := refined.Evaluate()
+		fmt.Printf("Refined -> %s (%d children)\n", strings.Join(refined, ""), uint((1.0-fitness)*1000.0))
+	}
 }
 
-func parseGenomeString(genome string) {
+func parseGenomeString(genome string, iset vm.InstructionSet) {
 	codons := strings.Split(genome, "")
 	for i := 0; i < len(codons); i++ {
-		switch codons[i] {
-		case "A":
-			fmt.Println("Nop")
-		case "B":
-			fmt.Println("Spawn Child")
-		case "C":
-			fmt.Println("Locate Food")
-		case "D":
-			fmt.Println("Eat Food")
-		case "E":
-			fmt.Println("Grow")
-		case "F":
-			fmt.Println("Defend")
-		case "G":
-			fmt.Println("Evade")
-		case "H":
-			fmt.Println("Skip if Low Threat")
-		case "I":
-			fmt.Println("Skip if Low Energy")
+		instr, ok := iset.Lookup(codons[i][0])
+		if !ok {
+			fmt.Printf("Unknown instruction %q\n", codons[i])
+			continue
 		}
+		fmt.Println(instr.Name())
 	}
 }
 
@@ -213,11 +254,43 @@ func evaluateSingleGenome(genomeString string) {
 	fmt.Println(fitness)
 }
 
+func refineGenome(genomeString string, cfg hillClimbConfig) {
+	seed := Genome(strings.Split(genomeString, ""))
+	refined := hillClimb(seed, cfg)
+	fitness, _ := refined.Evaluate()
+	fmt.Printf("%s (%d children)\n", strings.Join(refined, ""), uint((1.0-fitness)*1000.0))
+}
+
+// buildInstructionSet resolves a comma-separated --iset spec (e.g.
+// "default,loops") into the InstructionSet it describes. "default" is the
+// original 9-opcode organism ruleset; "loops" and "calls" are optional packs
+// that add extra opcodes on top.
+func buildInstructionSet(spec string) vm.InstructionSet {
+	var instructions []vm.Instruction
+
+	for _, pack := range strings.Split(spec, ",") {
+		switch strings.TrimSpace(pack) {
+		case "default", "":
+			instructions = append(instructions, vm.Default()...)
+		case "loops":
+			instructions = append(instructions, vm.Loops()...)
+		case "calls":
+			instructions = append(instructions, vm.Calls()...)
+		default:
+			fmt.Printf("Unknown instruction pack %q, ignoring\n", pack)
+		}
+	}
+
+	return vm.NewInstructionSet(instructions...)
+}
+
 func usage() {
 	fmt.Printf("Usage:\n")
 	fmt.Printf("\tga parse <genome> - Parses the genome into a textual representation\n")
-	fmt.Printf("\tga evolve <genomeLength> - Evolves genomes with the given fixed length\n")
+	fmt.Printf("\tga evolve <genomeLength> [flags] - Evolves genomes with the given fixed length (ignored with --variable-length)\n")
+	fmt.Printf("\tga refine <genome> [flags] - Hill-climbs a genome to its nearest local optimum\n")
 	fmt.Printf("\tga <genome> - Evaluates the fitness of the given genome\n")
+	fmt.Printf("\nFlags accepting --iset take a comma-separated list of instruction packs (default,loops,calls).\n")
 }
 
 func main() {
@@ -229,10 +302,67 @@ func main() {
 	} else if args[0] == "evolve" {
 		len := args[1]
 		uintLen, _ := strconv.ParseUint(len, 10, 32)
-		evolveGenomes(uintLen)
+
+		fs := flag.NewFlagSet("evolve", flag.ExitOnError)
+		maxStallGenerations := fs.Uint("max-stall-generations", 0, "stop the run if the champion hasn't improved in this many generations (0 disables)")
+		maxStallSeconds := fs.Float64("max-stall-seconds", 0, "stop the run if the champion hasn't improved in this many seconds (0 disables)")
+		targetFitness := fs.Float64("target-fitness", 0, "stop the run as soon as the champion's fitness reaches this value (0 disables)")
+		doHillclimb := fs.Bool("hillclimb", false, "hill-climb the final HallOfFame champion once the run stops")
+		hillclimbMaxRounds := fs.Uint("hillclimb-max-rounds", 0, "cap on hill-climbing sweeps (0 disables)")
+		hillclimbMaxSeconds := fs.Float64("hillclimb-max-seconds", 0, "cap on hill-climbing wall-clock time in seconds (0 disables)")
+		iset := fs.String("iset", "default", "comma-separated instruction packs to enable")
+		speciesThreshold := fs.Float64("species-threshold", 0, "cluster the population into species within this distance of each other (0 disables)")
+		speciesSharing := fs.String("species-sharing", "off", "on|off - multiply fitness by species size to stop one genotype crowding out the rest")
+		variableLengthMode := fs.Bool("variable-length", false, "sample genome length per-individual instead of using a fixed length")
+		minLen := fs.Uint("min-genome-length", 8, "shortest genome length allowed in variable-length mode")
+		maxLen := fs.Uint("max-genome-length", 64, "longest genome length allowed in variable-length mode")
+		pIns := fs.Float64("pins", 0.05, "per-mutation probability of inserting a random letter, in variable-length mode")
+		pDel := fs.Float64("pdel", 0.05, "per-mutation probability of deleting a letter, in variable-length mode")
+		parsimony := fs.Float64("parsimony-alpha", 0.0, "weight of the length penalty (alpha * len(G) / max-genome-length) added to fitness, in variable-length mode")
+		fitnessCacheSize := fs.Int("fitness-cache-size", 10000, "max entries in the genome->fitness LRU cache (0 disables)")
+		parallelEval := fs.Bool("parallel-eval", false, "evaluate individuals across populations concurrently")
+		fs.Parse(args[2:])
+
+		activeInstructionSet = buildInstructionSet(*iset)
+
+		evolveGenomes(uintLen, stallConfig{
+			maxGenerations: *maxStallGenerations,
+			maxSeconds:     *maxStallSeconds,
+			targetFitness:  *targetFitness,
+		}, *doHillclimb, hillClimbConfig{
+			maxRounds:  *hillclimbMaxRounds,
+			maxSeconds: *hillclimbMaxSeconds,
+		}, speciesConfig{
+			threshold: *speciesThreshold,
+			sharing:   *speciesSharing == "on",
+		}, varLenConfig{
+			enabled:    *variableLengthMode,
+			minLength:  *minLen,
+			maxLength:  *maxLen,
+			insertRate: *pIns,
+			deleteRate: *pDel,
+			alpha:      *parsimony,
+		}, *fitnessCacheSize, *parallelEval)
+	} else if args[0] == "refine" {
+		fs := flag.NewFlagSet("refine", flag.ExitOnError)
+		hillclimbMaxRounds := fs.Uint("hillclimb-max-rounds", 0, "cap on hill-climbing sweeps (0 disables)")
+		hillclimbMaxSeconds := fs.Float64("hillclimb-max-seconds", 0, "cap on hill-climbing wall-clock time in seconds (0 disables)")
+		iset := fs.String("iset", "default", "comma-separated instruction packs to enable")
+		fitnessCacheSize := fs.Int("fitness-cache-size", 10000, "max entries in the genome->fitness LRU cache (0 disables); hill-climbing re-evaluates near-duplicate genomes heavily")
+		fs.Parse(args[2:])
+
+		activeInstructionSet = buildInstructionSet(*iset)
+		activeFitnessCache = newFitnessCache(*fitnessCacheSize)
+
+		refineGenome(args[1], hillClimbConfig{
+			maxRounds:  *hillclimbMaxRounds,
+			maxSeconds: *hillclimbMaxSeconds,
+		})
 	} else if args[0] == "parse" {
-		parseGenomeString(args[1])
+		activeInstructionSet = buildInstructionSet("default")
+		parseGenomeString(args[1], activeInstructionSet)
 	} else {
+		activeInstructionSet = buildInstructionSet("default")
 		log.SetLevel(log.DebugLevel)
 		evaluateSingleGenome(args[0])
 	}
@@ -0,0 +1,55 @@
+package main
+
+import "time"
+
+// hillClimbConfig bounds how much work a hill-climbing pass is allowed to do.
+// A zero value for either field means "unbounded" for that dimension.
+type hillClimbConfig struct {
+	maxRounds  uint
+	maxSeconds float64
+}
+
+// hillClimb runs a deterministic 1-change local search over G: for every
+// locus and every corpus letter it substitutes the letter in, re-evaluates,
+// and keeps the change if it strictly improves fitness. Full sweeps repeat
+// until one produces no improvement or the round/time budget in cfg is
+// exhausted, whichever comes first.
+func hillClimb(G Genome, cfg hillClimbConfig) Genome {
+	best := *(G.Clone().(*Genome))
+	bestFitness, _ := best.Evaluate()
+
+	start := time.Now()
+
+	for round := uint(0); cfg.maxRounds == 0 || round < cfg.maxRounds; round++ {
+		if cfg.maxSeconds > 0 && time.Since(start).Seconds() >= cfg.maxSeconds {
+			break
+		}
+
+		improved := false
+
+		for i := 0; i < len(best); i++ {
+			current := best[i]
+
+			for _, c := range activeInstructionSet.Corpus() {
+				if c == current {
+					continue
+				}
+
+				best[i] = c
+				if fitness, _ := best.Evaluate(); fitness < bestFitness {
+					bestFitness = fitness
+					current = c
+					improved = true
+				}
+			}
+
+			best[i] = current
+		}
+
+		if !improved {
+			break
+		}
+	}
+
+	return best
+}
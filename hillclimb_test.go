@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/shteou/go-al/vm"
+)
+
+func TestHillClimbNeverWorsensFitness(t *testing.T) {
+	prevSet, prevCache, prevVariable := activeInstructionSet, activeFitnessCache, variableLength
+	activeInstructionSet = vm.NewInstructionSet(vm.Default()...)
+	activeFitnessCache = nil
+	variableLength = false
+	defer func() {
+		activeInstructionSet, activeFitnessCache, variableLength = prevSet, prevCache, prevVariable
+	}()
+
+	// "A" (no-op) never spawns a child, so its fitness is stuck at 1.0, and
+	// no single substitution can spawn one either (that takes both a 'C' and
+	// a 'D'); hill-climbing must at least not make fitness worse.
+	start := Genome{"A", "A", "A", "A"}
+	startFitness, _ := start.Evaluate()
+
+	climbed := hillClimb(start, hillClimbConfig{})
+	climbedFitness, _ := climbed.Evaluate()
+
+	if climbedFitness > startFitness {
+		t.Fatalf("expected hill-climbing to never make fitness worse: start=%v climbed=%v", startFitness, climbedFitness)
+	}
+}
+
+func TestHillClimbRespectsMaxRounds(t *testing.T) {
+	prevSet, prevCache, prevVariable := activeInstructionSet, activeFitnessCache, variableLength
+	activeInstructionSet = vm.NewInstructionSet(vm.Default()...)
+	activeFitnessCache = nil
+	variableLength = false
+	defer func() {
+		activeInstructionSet, activeFitnessCache, variableLength = prevSet, prevCache, prevVariable
+	}()
+
+	start := Genome{"A", "A", "A", "A"}
+
+	// A single round should run without panicking or looping forever, and
+	// still return a genome of the same length.
+	climbed := hillClimb(start, hillClimbConfig{maxRounds: 1})
+
+	if len(climbed) != len(start) {
+		t.Fatalf("expected hill-climbing to preserve genome length, got %d want %d", len(climbed), len(start))
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/MaxHalford/eaopt"
+)
+
+// variableLength is set by --variable-length; while it is on, Distance uses
+// Levenshtein rather than Hamming.
+var variableLength = false
+
+// Distance measures how dissimilar two genomes are, for species clustering.
+// Fixed-length genomes use Hamming distance; variable-length genomes use
+// Levenshtein distance so genomes of different lengths remain comparable.
+func Distance(a, b Genome) float64 {
+	if variableLength {
+		return levenshteinDistance(a, b)
+	}
+	return hammingDistance(a, b)
+}
+
+func hammingDistance(a, b Genome) float64 {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+
+	dist := math.Abs(float64(len(a) - len(b)))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			dist++
+		}
+	}
+
+	return dist
+}
+
+func levenshteinDistance(a, b Genome) float64 {
+	rows, cols := len(a)+1, len(b)+1
+
+	prev := make([]int, cols)
+	curr := make([]int, cols)
+	for j := 0; j < cols; j++ {
+		prev[j] = j
+	}
+
+	for i := 1; i < rows; i++ {
+		curr[0] = i
+		for j := 1; j < cols; j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev, curr = curr, prev
+	}
+
+	return float64(prev[cols-1])
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// speciesConfig controls the niching / fitness-sharing pass applied every
+// generation via ga.Callback.
+type speciesConfig struct {
+	threshold float64
+	sharing   bool
+}
+
+// individualRef locates an individual within ga.Populations so its fitness
+// can be adjusted in place.
+type individualRef struct {
+	pop *eaopt.Population
+	idx int
+}
+
+// clusterPopulations groups every individual across all subpopulations
+// using a leader-style threshold rule: walk the combined population once,
+// assigning each individual to the first cluster whose representative
+// (its first member) is within threshold, else starting a new cluster.
+//
+// This is still O(n) cluster comparisons per individual in the worst case
+// (every individual its own species), but each comparison first rejects on
+// the absolute genome-length difference - a lower bound on both Hamming and
+// Levenshtein distance - before paying for a full Distance call. That keeps
+// --variable-length runs, where Levenshtein is O(L^2) per pair, from paying
+// that cost for genomes whose lengths alone already put them outside
+// threshold, which is the common case once genomes have drifted apart.
+func clusterPopulations(ga *eaopt.GA, threshold float64) [][]individualRef {
+	var clusters [][]individualRef
+
+	for p := range ga.Populations {
+		pop := &ga.Populations[p]
+
+		for i := range pop.Individuals {
+			ref := individualRef{pop: pop, idx: i}
+			genome := *(pop.Individuals[i].Genome.(*Genome))
+
+			placed := false
+			for c, members := range clusters {
+				rep := *(members[0].pop.Individuals[members[0].idx].Genome.(*Genome))
+
+				if math.Abs(float64(len(genome)-len(rep))) > threshold {
+					continue
+				}
+
+				if Distance(genome, rep) <= threshold {
+					clusters[c] = append(clusters[c], ref)
+					placed = true
+					break
+				}
+			}
+
+			if !placed {
+				clusters = append(clusters, []individualRef{ref})
+			}
+		}
+	}
+
+	return clusters
+}
+
+// applySpeciation clusters the population into species and, if sharing is
+// enabled, multiplies each individual's fitness by its species size so a
+// single converged genotype can't crowd out other viable strategies. This
+// GA minimizes fitness (eaopt.SelTournament picks the lowest), so growing a
+// crowded individual's fitness makes it a worse tournament contestant
+// relative to rarer genotypes, which is the niching effect we want.
+// It logs the resulting cluster count and sizes either way.
+func applySpeciation(ga *eaopt.GA, cfg speciesConfig) {
+	if cfg.threshold <= 0 {
+		return
+	}
+
+	clusters := clusterPopulations(ga, cfg.threshold)
+
+	sizes := make([]int, len(clusters))
+	for i, members := range clusters {
+		sizes[i] = len(members)
+	}
+	fmt.Printf("%d) Species -> %d clusters, sizes %v\n", ga.Generations, len(clusters), sizes)
+
+	if !cfg.sharing {
+		return
+	}
+
+	for i, members := range clusters {
+		for _, ref := range members {
+			ref.pop.Individuals[ref.idx].Fitness *= float64(sizes[i])
+		}
+	}
+}
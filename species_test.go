@@ -0,0 +1,134 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/MaxHalford/eaopt"
+)
+
+func genomeIndividual(codons string, fitness float64) eaopt.Individual {
+	g := Genome([]string{})
+	for _, c := range codons {
+		g = append(g, string(c))
+	}
+	return eaopt.Individual{Genome: &g, Fitness: fitness}
+}
+
+func TestHammingDistance(t *testing.T) {
+	a := Genome{"A", "B", "C"}
+	b := Genome{"A", "B", "D"}
+
+	if d := hammingDistance(a, b); d != 1 {
+		t.Fatalf("expected distance 1, got %v", d)
+	}
+
+	if d := hammingDistance(a, a); d != 0 {
+		t.Fatalf("expected distance 0 for identical genomes, got %v", d)
+	}
+}
+
+func TestLevenshteinDistance(t *testing.T) {
+	a := Genome{"A", "B", "C"}
+	b := Genome{"A", "C"}
+
+	if d := levenshteinDistance(a, b); d != 1 {
+		t.Fatalf("expected distance 1 (single deletion), got %v", d)
+	}
+}
+
+func TestClusterPopulationsGroupsByThreshold(t *testing.T) {
+	ga := &eaopt.GA{
+		Populations: eaopt.Populations{
+			{
+				Individuals: eaopt.Individuals{
+					genomeIndividual("AAAA", 1.0),
+					genomeIndividual("AAAA", 1.0),
+					genomeIndividual("AAAA", 1.0),
+					genomeIndividual("BBBB", 1.0),
+				},
+			},
+		},
+	}
+
+	clusters := clusterPopulations(ga, 0)
+
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %d", len(clusters))
+	}
+
+	sizes := map[int]bool{}
+	for _, members := range clusters {
+		sizes[len(members)] = true
+	}
+	if !sizes[3] || !sizes[1] {
+		t.Fatalf("expected cluster sizes {3,1}, got clusters %v", clusters)
+	}
+}
+
+// TestClusterPopulationsLengthPreFilterMatchesFullDistance locks in that the
+// cheap length-difference pre-filter in clusterPopulations (a lower bound on
+// both Hamming and Levenshtein distance) produces the same clustering as
+// paying for the full distance on every pair would.
+func TestClusterPopulationsLengthPreFilterMatchesFullDistance(t *testing.T) {
+	prevVariable := variableLength
+	variableLength = true
+	defer func() { variableLength = prevVariable }()
+
+	ga := &eaopt.GA{
+		Populations: eaopt.Populations{
+			{
+				Individuals: eaopt.Individuals{
+					genomeIndividual("AAAA", 1.0),
+					genomeIndividual("AAAA", 1.0),
+					// Length alone (8 vs 4) already exceeds the threshold, so
+					// this must land in its own cluster without the content
+					// ever needing a full Levenshtein comparison.
+					genomeIndividual("BBBBBBBB", 1.0),
+				},
+			},
+		},
+	}
+
+	clusters := clusterPopulations(ga, 1)
+
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %d", len(clusters))
+	}
+
+	sizes := map[int]bool{}
+	for _, members := range clusters {
+		sizes[len(members)] = true
+	}
+	if !sizes[2] || !sizes[1] {
+		t.Fatalf("expected cluster sizes {2,1}, got clusters %v", clusters)
+	}
+}
+
+// TestApplySpeciationPenalisesCrowdedGenotypes locks in the niching
+// direction: this GA minimizes fitness (eaopt.SelTournament picks the
+// individual with the *lowest* fitness as the winner), so sharing must
+// leave crowded genotypes with a *worse* (higher) fitness than rare ones,
+// not a better one.
+func TestApplySpeciationPenalisesCrowdedGenotypes(t *testing.T) {
+	ga := &eaopt.GA{
+		Populations: eaopt.Populations{
+			{
+				Individuals: eaopt.Individuals{
+					genomeIndividual("AAAA", 1.0), // crowded genotype
+					genomeIndividual("AAAA", 1.0),
+					genomeIndividual("AAAA", 1.0),
+					genomeIndividual("BBBB", 1.0), // rare genotype
+				},
+			},
+		},
+	}
+
+	applySpeciation(ga, speciesConfig{threshold: 0.5, sharing: true})
+
+	crowded := ga.Populations[0].Individuals[0].Fitness
+	rare := ga.Populations[0].Individuals[3].Fitness
+
+	if !(crowded > rare) {
+		t.Fatalf("expected crowded genotype fitness (%v) to end up worse (higher) than rare genotype fitness (%v)", crowded, rare)
+	}
+}
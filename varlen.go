@@ -0,0 +1,30 @@
+package main
+
+import "math/rand"
+
+// Variable-length genome settings. variableLength itself lives in
+// species.go since Distance already needed to know about it; the rest of
+// the knobs live here alongside the code that uses them.
+var (
+	minGenomeLength uint
+	maxGenomeLength uint
+	insertRate      float64
+	deleteRate      float64
+	parsimonyAlpha  float64
+)
+
+// clampGenomeLength trims or pads g so it stays within
+// [minGenomeLength, maxGenomeLength] after a variable-length mutation or
+// crossover has changed its size.
+func clampGenomeLength(g Genome, rng *rand.Rand) Genome {
+	if uint(len(g)) > maxGenomeLength {
+		g = g[:maxGenomeLength]
+	}
+
+	corpus := activeInstructionSet.Corpus()
+	for uint(len(g)) < minGenomeLength {
+		g = append(g, corpus[rng.Intn(len(corpus))])
+	}
+
+	return g
+}
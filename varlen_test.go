@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/shteou/go-al/vm"
+)
+
+func withVarLenFixture(t *testing.T, minLen, maxLen uint, fn func()) {
+	t.Helper()
+
+	prevSet := activeInstructionSet
+	prevVariable := variableLength
+	prevMin, prevMax := minGenomeLength, maxGenomeLength
+
+	activeInstructionSet = vm.NewInstructionSet(vm.Default()...)
+	variableLength = true
+	minGenomeLength = minLen
+	maxGenomeLength = maxLen
+
+	defer func() {
+		activeInstructionSet = prevSet
+		variableLength = prevVariable
+		minGenomeLength, maxGenomeLength = prevMin, prevMax
+	}()
+
+	fn()
+}
+
+func TestClampGenomeLengthPadsShortGenomes(t *testing.T) {
+	withVarLenFixture(t, 4, 10, func() {
+		rng := rand.New(rand.NewSource(1))
+		g := clampGenomeLength(Genome{"A"}, rng)
+
+		if len(g) != 4 {
+			t.Fatalf("expected genome to be padded up to min length 4, got %d", len(g))
+		}
+	})
+}
+
+func TestClampGenomeLengthTrimsLongGenomes(t *testing.T) {
+	withVarLenFixture(t, 1, 3, func() {
+		rng := rand.New(rand.NewSource(1))
+		g := clampGenomeLength(Genome{"A", "B", "C", "D", "E"}, rng)
+
+		if len(g) != 3 {
+			t.Fatalf("expected genome to be trimmed down to max length 3, got %d", len(g))
+		}
+	})
+}
+
+func TestCrossoverProducesGenomesWithinBounds(t *testing.T) {
+	withVarLenFixture(t, 4, 8, func() {
+		rng := rand.New(rand.NewSource(1))
+
+		a := Genome{"A", "B", "C", "D", "E"}
+		b := Genome{"A", "A", "A", "A", "A", "A"}
+
+		a.Crossover(&b, rng)
+
+		if uint(len(a)) < minGenomeLength || uint(len(a)) > maxGenomeLength {
+			t.Fatalf("expected first child within [%d,%d], got length %d", minGenomeLength, maxGenomeLength, len(a))
+		}
+		if uint(len(b)) < minGenomeLength || uint(len(b)) > maxGenomeLength {
+			t.Fatalf("expected second child within [%d,%d], got length %d", minGenomeLength, maxGenomeLength, len(b))
+		}
+	})
+}
+
+func TestMutateRespectsLengthBounds(t *testing.T) {
+	withVarLenFixture(t, 2, 2, func() {
+		insertRate, deleteRate = 1.0, 1.0
+		defer func() { insertRate, deleteRate = 0, 0 }()
+
+		rng := rand.New(rand.NewSource(1))
+		g := Genome{"A", "B"}
+
+		// min == max == 2, so neither the guaranteed insert nor the
+		// guaranteed delete should be able to move the length off 2.
+		g.Mutate(rng)
+
+		if len(g) != 2 {
+			t.Fatalf("expected genome length to stay pinned at 2, got %d", len(g))
+		}
+	})
+}
@@ -0,0 +1,142 @@
+package vm
+
+import "math"
+
+type noOp struct{}
+
+func (noOp) Symbol() byte { return 'A' }
+func (noOp) Name() string { return "Nop" }
+func (noOp) Exec(state *GenomeState, ip *int) ControlFlow {
+	logWithFields(state).Debug("No Op")
+	return Advance
+}
+
+type spawnChild struct{}
+
+func (spawnChild) Symbol() byte { return 'B' }
+func (spawnChild) Name() string { return "Spawn Child" }
+func (spawnChild) Exec(state *GenomeState, ip *int) ControlFlow {
+	if state.Energy > 25.0 {
+		state.Children++
+		logWithFields(state).Debug("Spawn Child succeeded")
+	} else {
+		logWithFields(state).Debug("Spawn Child failed")
+	}
+	state.Energy -= 25.0
+
+	forgetFood(state)
+	applyMetabolicCost(state)
+	return Advance
+}
+
+type locateFood struct{}
+
+func (locateFood) Symbol() byte { return 'C' }
+func (locateFood) Name() string { return "Locate Food" }
+func (locateFood) Exec(state *GenomeState, ip *int) ControlFlow {
+	state.FoundFood = true
+	logWithFields(state).Debug("Located Food")
+
+	applyMetabolicCost(state)
+	return Advance
+}
+
+type eatFood struct{}
+
+func (eatFood) Symbol() byte { return 'D' }
+func (eatFood) Name() string { return "Eat Food" }
+func (eatFood) Exec(state *GenomeState, ip *int) ControlFlow {
+	if state.FoundFood {
+		logWithFields(state).Debug("Ate Food")
+		state.Energy += 10.0 + float64(state.Size)
+		state.Energy = math.Max(state.Energy, float64(state.Size)+15.0)
+	} else {
+		logWithFields(state).Debug("Eat Food Failed")
+	}
+
+	forgetFood(state)
+	applyMetabolicCost(state)
+	return Advance
+}
+
+type grow struct{}
+
+func (grow) Symbol() byte { return 'E' }
+func (grow) Name() string { return "Grow" }
+func (grow) Exec(state *GenomeState, ip *int) ControlFlow {
+	logWithFields(state).Debug("Growing")
+	state.Energy -= math.Pow(float64(state.Size)/2.0, 1.05)
+	state.Size++
+
+	forgetFood(state)
+	applyMetabolicCost(state)
+	return Advance
+}
+
+type defend struct{}
+
+func (defend) Symbol() byte { return 'F' }
+func (defend) Name() string { return "Defend" }
+func (defend) Exec(state *GenomeState, ip *int) ControlFlow {
+	logWithFields(state).Debug("Defending")
+	state.Energy -= 5.0 / (float64(state.Size) / 2.0)
+	state.Threat -= 5
+	state.Threat = math.Max(state.Threat, 0.0)
+
+	forgetFood(state)
+	applyMetabolicCost(state)
+	return Advance
+}
+
+type evade struct{}
+
+func (evade) Symbol() byte { return 'G' }
+func (evade) Name() string { return "Evade" }
+func (evade) Exec(state *GenomeState, ip *int) ControlFlow {
+	logWithFields(state).Debug("Evading")
+	state.Energy -= 1.0 * (float64(state.Size) / 2.0)
+	state.Threat -= 5
+
+	forgetFood(state)
+	applyMetabolicCost(state)
+	return Advance
+}
+
+type skipIfLowThreat struct{}
+
+func (skipIfLowThreat) Symbol() byte { return 'H' }
+func (skipIfLowThreat) Name() string { return "Skip if Low Threat" }
+func (skipIfLowThreat) Exec(state *GenomeState, ip *int) ControlFlow {
+	if state.Threat < 5.0 {
+		logWithFields(state).Debug("Skipping due to low threat")
+		return Skip
+	}
+	return Advance
+}
+
+type skipIfLowEnergy struct{}
+
+func (skipIfLowEnergy) Symbol() byte { return 'I' }
+func (skipIfLowEnergy) Name() string { return "Skip if Low Energy" }
+func (skipIfLowEnergy) Exec(state *GenomeState, ip *int) ControlFlow {
+	if state.Energy < 30.0 {
+		logWithFields(state).Debug("Skipping due to low energy")
+		return Skip
+	}
+	return Advance
+}
+
+// Default returns the original 9-instruction organism ruleset.
+func Default() []Instruction {
+	return []Instruction{
+		noOp{},
+		spawnChild{},
+		locateFood{},
+		eatFood{},
+		grow{},
+		defend{},
+		evade{},
+		skipIfLowThreat{},
+		skipIfLowEnergy{},
+	}
+}
@@ -0,0 +1,51 @@
+package vm
+
+// maxCallDepth bounds the subroutine call stack so a runaway recursive
+// genome can't grow it unboundedly.
+const maxCallDepth = 8
+
+// Subroutines always start at genome position 0 — there's no operand
+// encoding for a call target — so Call pushes a return address and jumps to
+// the start, and Return pops it back off. Both pay the usual metabolic cost
+// on the jump they take, same as any other state-mutating instruction.
+
+type call struct{}
+
+func (call) Symbol() byte { return 'L' }
+func (call) Name() string { return "Call Subroutine" }
+func (call) Exec(state *GenomeState, ip *int) ControlFlow {
+	if len(state.CallStack) >= maxCallDepth {
+		logWithFields(state).Debug("Call ignored, stack full")
+		return Advance
+	}
+
+	state.CallStack = append(state.CallStack, *ip+1)
+	*ip = 0
+	forgetFood(state)
+	applyMetabolicCost(state)
+	return Jumped
+}
+
+type ret struct{}
+
+func (ret) Symbol() byte { return 'M' }
+func (ret) Name() string { return "Return" }
+func (ret) Exec(state *GenomeState, ip *int) ControlFlow {
+	if len(state.CallStack) == 0 {
+		logWithFields(state).Debug("Return with empty stack, ignored")
+		return Advance
+	}
+
+	last := len(state.CallStack) - 1
+	*ip = state.CallStack[last]
+	state.CallStack = state.CallStack[:last]
+	forgetFood(state)
+	applyMetabolicCost(state)
+	return Jumped
+}
+
+// Calls is an optional pack adding a minimal subroutine call/return pair
+// ('L' call, 'M' return).
+func Calls() []Instruction {
+	return []Instruction{call{}, ret{}}
+}
@@ -0,0 +1,69 @@
+package vm
+
+import "testing"
+
+func TestCallPushesReturnAddressAndJumpsToStart(t *testing.T) {
+	state := &GenomeState{Energy: 10.0}
+	ip := 5
+
+	if flow := (call{}).Exec(state, &ip); flow != Jumped {
+		t.Fatalf("expected Call to report Jumped, got %v", flow)
+	}
+	if ip != 0 {
+		t.Fatalf("expected Call to jump to genome position 0, got %d", ip)
+	}
+	if len(state.CallStack) != 1 || state.CallStack[0] != 6 {
+		t.Fatalf("expected the return address 6 to be pushed, got %v", state.CallStack)
+	}
+	if state.Energy >= 10.0 {
+		t.Fatalf("expected Call to pay the usual metabolic cost, got Energy=%v", state.Energy)
+	}
+}
+
+func TestCallIgnoredWhenStackIsFull(t *testing.T) {
+	state := &GenomeState{Energy: 10.0, CallStack: make([]int, maxCallDepth)}
+	ip := 5
+
+	if flow := (call{}).Exec(state, &ip); flow != Advance {
+		t.Fatalf("expected Call to be ignored once the stack is full, got %v", flow)
+	}
+	if ip != 5 {
+		t.Fatalf("expected ip to stay put when Call is ignored, got %d", ip)
+	}
+	if len(state.CallStack) != maxCallDepth {
+		t.Fatalf("expected the call stack to stay at maxCallDepth, got %d", len(state.CallStack))
+	}
+}
+
+func TestReturnPopsStackAndJumpsBack(t *testing.T) {
+	state := &GenomeState{Energy: 10.0, CallStack: []int{6}}
+	ip := 0
+
+	if flow := (ret{}).Exec(state, &ip); flow != Jumped {
+		t.Fatalf("expected Return to report Jumped, got %v", flow)
+	}
+	if ip != 6 {
+		t.Fatalf("expected Return to jump back to the pushed return address, got %d", ip)
+	}
+	if len(state.CallStack) != 0 {
+		t.Fatalf("expected Return to pop the call stack, got %v", state.CallStack)
+	}
+	if state.Energy >= 10.0 {
+		t.Fatalf("expected Return to pay the usual metabolic cost, got Energy=%v", state.Energy)
+	}
+}
+
+func TestReturnIgnoredWithEmptyStack(t *testing.T) {
+	state := &GenomeState{Energy: 10.0}
+	ip := 3
+
+	if flow := (ret{}).Exec(state, &ip); flow != Advance {
+		t.Fatalf("expected Return with an empty stack to be ignored, got %v", flow)
+	}
+	if ip != 3 {
+		t.Fatalf("expected ip to stay put when Return is ignored, got %d", ip)
+	}
+	if state.Energy != 10.0 {
+		t.Fatalf("expected no metabolic cost when Return is ignored, got Energy=%v", state.Energy)
+	}
+}
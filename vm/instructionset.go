@@ -0,0 +1,54 @@
+package vm
+
+// InstructionSet owns the dispatch table and corpus of symbols an organism
+// program is drawn from. Genome operators (Mutate, Crossover,
+// InitUnifString) read Corpus() from the active InstructionSet instead of a
+// hard-coded package variable, so a genome's alphabet always matches the
+// instructions that will execute it.
+type InstructionSet struct {
+	instructions map[byte]Instruction
+	order        []Instruction
+}
+
+// NewInstructionSet builds an InstructionSet from the given instructions,
+// preserving registration order for Corpus() and Instructions(). Later
+// instructions win ties on a duplicate symbol.
+func NewInstructionSet(instructions ...Instruction) InstructionSet {
+	iset := InstructionSet{instructions: make(map[byte]Instruction, len(instructions))}
+
+	for _, instr := range instructions {
+		if _, exists := iset.instructions[instr.Symbol()]; !exists {
+			iset.order = append(iset.order, instr)
+		} else {
+			for i := range iset.order {
+				if iset.order[i].Symbol() == instr.Symbol() {
+					iset.order[i] = instr
+				}
+			}
+		}
+		iset.instructions[instr.Symbol()] = instr
+	}
+
+	return iset
+}
+
+// Lookup returns the instruction registered for symbol, if any.
+func (iset InstructionSet) Lookup(symbol byte) (Instruction, bool) {
+	instr, ok := iset.instructions[symbol]
+	return instr, ok
+}
+
+// Instructions returns the registered instructions in registration order.
+func (iset InstructionSet) Instructions() []Instruction {
+	return iset.order
+}
+
+// Corpus returns the single-character alphabet that Mutate, Crossover, and
+// InitUnifString should sample from.
+func (iset InstructionSet) Corpus() []string {
+	corpus := make([]string, 0, len(iset.order))
+	for _, instr := range iset.order {
+		corpus = append(corpus, string(instr.Symbol()))
+	}
+	return corpus
+}
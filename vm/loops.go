@@ -0,0 +1,50 @@
+package vm
+
+// maxLoopBack is how far back a loop instruction jumps, clamped at genome
+// position 0. A jump taken within the first maxLoopBack positions lands on
+// 0, so a loop there can keep the program from reaching the rest of the
+// genome, and a 'K' at position 0 jumps onto itself.
+//
+// Every jump taken still pays the usual metabolic cost, same as any other
+// state-mutating instruction, so a tight loop drains energy rather than
+// running for free.
+const maxLoopBack = 8
+
+type jumpIfThreatened struct{}
+
+func (jumpIfThreatened) Symbol() byte { return 'J' }
+func (jumpIfThreatened) Name() string { return "Jump Back if Threatened" }
+func (jumpIfThreatened) Exec(state *GenomeState, ip *int) ControlFlow {
+	if state.Threat > 0 {
+		*ip = jumpBack(*ip)
+		forgetFood(state)
+		applyMetabolicCost(state)
+		return Jumped
+	}
+	return Advance
+}
+
+type jumpBackAlways struct{}
+
+func (jumpBackAlways) Symbol() byte { return 'K' }
+func (jumpBackAlways) Name() string { return "Jump Back" }
+func (jumpBackAlways) Exec(state *GenomeState, ip *int) ControlFlow {
+	*ip = jumpBack(*ip)
+	forgetFood(state)
+	applyMetabolicCost(state)
+	return Jumped
+}
+
+func jumpBack(ip int) int {
+	if ip < maxLoopBack {
+		return 0
+	}
+	return ip - maxLoopBack
+}
+
+// Loops is an optional pack adding bounded backward jumps ('J' conditional
+// on threat, 'K' unconditional), letting genomes express tight loops rather
+// than relying purely on the genome wrapping around at its end.
+func Loops() []Instruction {
+	return []Instruction{jumpIfThreatened{}, jumpBackAlways{}}
+}
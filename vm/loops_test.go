@@ -0,0 +1,55 @@
+package vm
+
+import "testing"
+
+func TestJumpBackAlwaysJumpsAndPaysMetabolicCost(t *testing.T) {
+	state := &GenomeState{Energy: 10.0}
+	ip := maxLoopBack + 5
+
+	if flow := (jumpBackAlways{}).Exec(state, &ip); flow != Jumped {
+		t.Fatalf("expected Jump Back to report Jumped, got %v", flow)
+	}
+	if ip != 5 {
+		t.Fatalf("expected ip to move back by maxLoopBack, got %d", ip)
+	}
+	if state.Energy >= 10.0 {
+		t.Fatalf("expected Jump Back to pay the usual metabolic cost, got Energy=%v", state.Energy)
+	}
+}
+
+func TestJumpBackClampsAtZero(t *testing.T) {
+	state := &GenomeState{Energy: 10.0}
+	ip := 2
+
+	(jumpBackAlways{}).Exec(state, &ip)
+	if ip != 0 {
+		t.Fatalf("expected a jump near the start of the genome to clamp at 0, got %d", ip)
+	}
+}
+
+func TestJumpIfThreatenedOnlyJumpsWhenThreatened(t *testing.T) {
+	state := &GenomeState{Energy: 10.0, Threat: 0}
+	ip := 5
+
+	if flow := (jumpIfThreatened{}).Exec(state, &ip); flow != Advance {
+		t.Fatalf("expected Advance when not threatened, got %v", flow)
+	}
+	if ip != 5 {
+		t.Fatalf("expected ip to stay put when not threatened, got %d", ip)
+	}
+	if state.Energy != 10.0 {
+		t.Fatalf("expected no metabolic cost when the jump isn't taken, got Energy=%v", state.Energy)
+	}
+
+	state = &GenomeState{Energy: 10.0, Threat: 1}
+	ip = maxLoopBack + 5
+	if flow := (jumpIfThreatened{}).Exec(state, &ip); flow != Jumped {
+		t.Fatalf("expected Jumped when threatened, got %v", flow)
+	}
+	if ip != 5 {
+		t.Fatalf("expected ip to move back by maxLoopBack, got %d", ip)
+	}
+	if state.Energy >= 10.0 {
+		t.Fatalf("expected the taken jump to pay the usual metabolic cost, got Energy=%v", state.Energy)
+	}
+}
@@ -0,0 +1,131 @@
+// Package vm implements a tiny interpreter for genome programs. Which
+// opcodes exist and what they do to the organism is decided entirely by the
+// InstructionSet passed to Run, so callers can experiment with different
+// rulesets without touching the GA plumbing that drives them.
+package vm
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// GenomeState is the mutable organism state instructions read and write as a
+// program executes.
+type GenomeState struct {
+	Children  uint
+	Energy    float64
+	FoundFood bool
+	Size      uint
+	Threat    float64
+	CallStack []int
+}
+
+// ControlFlow tells Run how to move the instruction pointer once an
+// instruction has finished executing.
+type ControlFlow int
+
+const (
+	// Advance moves to the next instruction in program order.
+	Advance ControlFlow = iota
+	// Skip moves two instructions ahead, skipping the one immediately after.
+	Skip
+	// Jumped indicates the instruction already repointed ip itself; Run only
+	// wraps it back into the program's bounds.
+	Jumped
+)
+
+// Instruction is a single opcode an InstructionSet can dispatch to.
+type Instruction interface {
+	// Symbol is the single byte a genome uses to select this instruction.
+	Symbol() byte
+	// Name is a human-readable label, used by `ga parse`.
+	Name() string
+	// Exec applies the instruction to state, optionally repointing ip for
+	// jump-like instructions, and reports how Run should advance afterwards.
+	Exec(state *GenomeState, ip *int) ControlFlow
+}
+
+func logWithFields(state *GenomeState) *log.Entry {
+	return log.WithFields(log.Fields{
+		"state": state,
+	})
+}
+
+// applyMetabolicCost charges the background energy/threat cost every
+// instruction pays except the memory-only no-ops (NoOp and the two
+// conditional skips).
+func applyMetabolicCost(state *GenomeState) {
+	state.Energy -= math.Pow(1.0+float64(state.Size)/40.0, 2.0)
+	state.Threat++
+
+	if state.Threat > 20.0+float64(state.Size) {
+		state.Energy -= state.Threat
+	}
+}
+
+// forgetFood clears the organism's memory of having found food. NoOp,
+// LocateFood, and the two conditional skips are exempt and call it out
+// selectively rather than unconditionally.
+func forgetFood(state *GenomeState) {
+	state.FoundFood = false
+}
+
+// statePool recycles GenomeState instances across Run calls. Evaluate can be
+// invoked many thousands of times per generation, and once ParallelEval is
+// turned on those calls happen concurrently, so this trades a per-call heap
+// allocation for a pool that's safe to share across goroutines.
+var statePool = sync.Pool{
+	New: func() interface{} { return new(GenomeState) },
+}
+
+// Run executes program against iset for up to maxSteps iterations, or until
+// the organism runs out of energy, and returns the resulting state.
+func Run(program []byte, iset InstructionSet, maxSteps int) (GenomeState, error) {
+	if len(program) == 0 {
+		return GenomeState{}, fmt.Errorf("vm: cannot run an empty program")
+	}
+
+	state := statePool.Get().(*GenomeState)
+	callStack := state.CallStack[:0]
+	*state = GenomeState{Energy: 10.0, Size: 1, CallStack: callStack}
+	defer statePool.Put(state)
+
+	ip := 0
+
+	for i := 0; i < maxSteps; i++ {
+		symbol := program[ip]
+
+		if instr, ok := iset.Lookup(symbol); ok {
+			switch instr.Exec(state, &ip) {
+			case Skip:
+				ip = (ip + 2) % len(program)
+			case Jumped:
+				ip = ((ip % len(program)) + len(program)) % len(program)
+			default:
+				ip = (ip + 1) % len(program)
+			}
+		} else {
+			logWithFields(state).Debugf("Unexpected symbol %q", symbol)
+			forgetFood(state)
+			applyMetabolicCost(state)
+			ip = (ip + 1) % len(program)
+		}
+
+		if state.Energy <= 0.0 {
+			logWithFields(state).Debugf("Died on iteration %d", i)
+			break
+		}
+	}
+
+	// state.CallStack's backing array belongs to the pool and gets reused by
+	// the next Run as state.CallStack[:0], so a shallow copy of *state would
+	// let a caller that reads CallStack see it silently overwritten by a
+	// later, unrelated Run. Give the result its own backing array instead.
+	result := *state
+	result.CallStack = append([]int(nil), state.CallStack...)
+
+	return result, nil
+}
@@ -0,0 +1,174 @@
+package vm
+
+import "testing"
+
+func TestInstructionSetLookupAndCorpus(t *testing.T) {
+	iset := NewInstructionSet(Default()...)
+
+	if _, ok := iset.Lookup('A'); !ok {
+		t.Fatalf("expected symbol 'A' to be registered")
+	}
+	if _, ok := iset.Lookup('Z'); ok {
+		t.Fatalf("did not expect symbol 'Z' to be registered")
+	}
+
+	corpus := iset.Corpus()
+	if len(corpus) != len(Default()) {
+		t.Fatalf("expected corpus of length %d, got %d", len(Default()), len(corpus))
+	}
+	if corpus[0] != "A" {
+		t.Fatalf("expected corpus to preserve registration order, got %v", corpus)
+	}
+}
+
+func TestSpawnChildRequiresEnergy(t *testing.T) {
+	state := &GenomeState{Energy: 30.0}
+	spawnChild{}.Exec(state, new(int))
+	if state.Children != 1 {
+		t.Fatalf("expected Spawn Child to succeed with sufficient energy, got Children=%d", state.Children)
+	}
+
+	state = &GenomeState{Energy: 10.0}
+	spawnChild{}.Exec(state, new(int))
+	if state.Children != 0 {
+		t.Fatalf("expected Spawn Child to fail with insufficient energy, got Children=%d", state.Children)
+	}
+}
+
+func TestLocateFoodThenEatFood(t *testing.T) {
+	state := &GenomeState{Energy: 10.0, Size: 1}
+	locateFood{}.Exec(state, new(int))
+	if !state.FoundFood {
+		t.Fatalf("expected Locate Food to set FoundFood")
+	}
+
+	energyBefore := state.Energy
+	eatFood{}.Exec(state, new(int))
+	if state.Energy <= energyBefore {
+		t.Fatalf("expected Eat Food to increase energy after locating food, before=%v after=%v", energyBefore, state.Energy)
+	}
+	if state.FoundFood {
+		t.Fatalf("expected Eat Food to clear FoundFood afterwards")
+	}
+}
+
+func TestEatFoodWithoutLocatingDoesNotRestoreEnergy(t *testing.T) {
+	state := &GenomeState{Energy: 10.0, Size: 1}
+	energyBefore := state.Energy
+	eatFood{}.Exec(state, new(int))
+	if state.Energy >= energyBefore {
+		t.Fatalf("expected Eat Food without food found to only pay metabolic cost, before=%v after=%v", energyBefore, state.Energy)
+	}
+}
+
+func TestGrowIncreasesSize(t *testing.T) {
+	state := &GenomeState{Energy: 10.0, Size: 1}
+	grow{}.Exec(state, new(int))
+	if state.Size != 2 {
+		t.Fatalf("expected Grow to increment Size, got %d", state.Size)
+	}
+}
+
+func TestDefendAndEvadeReduceThreat(t *testing.T) {
+	// Exec's own Threat-=5 is followed by applyMetabolicCost's Threat++, so
+	// the net change is -4, not -5.
+	state := &GenomeState{Energy: 10.0, Size: 2, Threat: 10.0}
+	defend{}.Exec(state, new(int))
+	if state.Threat != 6.0 {
+		t.Fatalf("expected Defend to leave Threat at 6 (10-5+1), got %v", state.Threat)
+	}
+
+	state = &GenomeState{Energy: 10.0, Size: 2, Threat: 2.0}
+	defend{}.Exec(state, new(int))
+	if state.Threat != 1.0 {
+		t.Fatalf("expected Defend to clamp the -5 at 0 before the metabolic +1, got %v", state.Threat)
+	}
+
+	state = &GenomeState{Energy: 10.0, Size: 2, Threat: 10.0}
+	evade{}.Exec(state, new(int))
+	if state.Threat != 6.0 {
+		t.Fatalf("expected Evade to leave Threat at 6 (10-5+1), got %v", state.Threat)
+	}
+}
+
+func TestSkipIfLowThreatAndLowEnergy(t *testing.T) {
+	low := &GenomeState{Threat: 1.0}
+	if flow := (skipIfLowThreat{}).Exec(low, new(int)); flow != Skip {
+		t.Fatalf("expected Skip when threat is low, got %v", flow)
+	}
+
+	high := &GenomeState{Threat: 10.0}
+	if flow := (skipIfLowThreat{}).Exec(high, new(int)); flow != Advance {
+		t.Fatalf("expected Advance when threat is high, got %v", flow)
+	}
+
+	lowEnergy := &GenomeState{Energy: 5.0}
+	if flow := (skipIfLowEnergy{}).Exec(lowEnergy, new(int)); flow != Skip {
+		t.Fatalf("expected Skip when energy is low, got %v", flow)
+	}
+
+	highEnergy := &GenomeState{Energy: 100.0}
+	if flow := (skipIfLowEnergy{}).Exec(highEnergy, new(int)); flow != Advance {
+		t.Fatalf("expected Advance when energy is high, got %v", flow)
+	}
+}
+
+func TestRunRejectsEmptyProgram(t *testing.T) {
+	iset := NewInstructionSet(Default()...)
+	if _, err := Run(nil, iset, 10); err == nil {
+		t.Fatalf("expected an error running an empty program")
+	}
+}
+
+func TestRunStopsOnZeroEnergy(t *testing.T) {
+	iset := NewInstructionSet(Default()...)
+	state, err := Run([]byte("E"), iset, 1000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Energy > 0.0 {
+		t.Fatalf("expected Run to stop once energy is depleted, got Energy=%v", state.Energy)
+	}
+}
+
+func TestRunSkipsUnknownSymbol(t *testing.T) {
+	iset := NewInstructionSet(Default()...)
+	// 'Z' isn't registered, so Run should treat it as a metabolic no-op and
+	// continue to the following instruction rather than failing.
+	state, err := Run([]byte("ZA"), iset, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Energy >= 10.0 {
+		t.Fatalf("expected the unknown symbol to still pay metabolic cost, got Energy=%v", state.Energy)
+	}
+}
+
+// TestRunReturnsAnIndependentCallStack guards against statePool.Put recycling
+// a GenomeState's CallStack backing array into a later Run that a caller is
+// still holding a reference to via an earlier result.
+func TestRunReturnsAnIndependentCallStack(t *testing.T) {
+	iset := NewInstructionSet(append(Default(), Calls()...)...)
+
+	first, err := Run([]byte("L"), iset, 30)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first.CallStack) != maxCallDepth {
+		t.Fatalf("expected the first run's call stack to fill up to %d, got %v", maxCallDepth, first.CallStack)
+	}
+
+	firstCallStack := append([]int(nil), first.CallStack...)
+
+	// A second Run recycles the same pooled GenomeState (and its CallStack
+	// backing array) and pushes different return addresses onto it.
+	if _, err := Run([]byte("AL"), iset, 30); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, v := range firstCallStack {
+		if first.CallStack[i] != v {
+			t.Fatalf("expected the first run's CallStack to be unaffected by a later Run, want %v got %v", firstCallStack, first.CallStack)
+		}
+	}
+}